@@ -10,7 +10,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
 
 	"github.com/moov-io/bai2/pkg/lib"
 	"github.com/moov-io/bai2/pkg/record"
@@ -45,6 +50,13 @@ type Bai2 struct {
 	Header  *lib.FileHeader
 	Groups  []*Group
 	Trailer *lib.FileTrailer
+
+	// HeaderLine and TrailerLine hold the source line number of Header
+	// and Trailer, populated by Parse so ValidateMode can point Strict
+	// and BankSpecific violations at where they occurred. Both are
+	// zero for a Bai2 built by hand (e.g. via Writer).
+	HeaderLine  int
+	TrailerLine int
 }
 
 func (r *Bai2) String() string {
@@ -98,6 +110,16 @@ type Group struct {
 	Header  *lib.GroupHeader
 	Details []record.Record
 	Trailer *lib.GroupTrailer
+
+	// HeaderLine, DetailLines, and TrailerLine hold the source line
+	// number of Header, each entry of Details, and Trailer
+	// respectively, populated by Parse so ValidateMode can point
+	// Strict and BankSpecific violations at where they occurred.
+	// DetailLines is indexed the same as Details; all three are left
+	// zero/nil for a Group built by hand (e.g. via NewGroup or Writer).
+	HeaderLine  int
+	DetailLines []int
+	TrailerLine int
 }
 
 func (r *Group) String() string {
@@ -141,134 +163,329 @@ func (r *Group) Validate() error {
 	return nil
 }
 
+// recordCode extracts the two-character record code and the normalized
+// line (stripped of its newline and leading junk before the code) from a
+// raw token produced by scanRecord.
+func recordCode(raw string) (code string, line string, ok bool) {
+	line = strings.ReplaceAll(raw, "\n", "")
+
+	// find record code
+	recordIndex := strings.Index(line, ",")
+	if recordIndex < 2 {
+		return "", "", false
+	}
+	line = line[recordIndex-2:]
+
+	return line[0:2], line, true
+}
+
+// newRecordFor constructs the lib record matching a BAI2 record code.
+func newRecordFor(code string) (record.Record, error) {
+	switch code {
+	case "01":
+		return lib.NewFileHeader(), nil
+	case "02":
+		return lib.NewGroupHeader(), nil
+	case "03":
+		return lib.NewAccountIdentifier(), nil
+	case "16":
+		return lib.NewTransactionDetail(), nil
+	case "49":
+		return lib.NewAccountTrailer(), nil
+	case "88":
+		return lib.NewContinuationRecord(), nil
+	case "98":
+		return lib.NewGroupTrailer(), nil
+	case "99":
+		return lib.NewFileTrailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown record code %q", code)
+	}
+}
+
+// ParseOptions controls how Parse reads and validates a BAI2 file.
+type ParseOptions struct {
+	// ContinueOnError makes Parse collect every malformed record it
+	// finds across the whole file instead of aborting on the first
+	// one. The collected errors are returned together via errors.Join.
+	ContinueOnError bool
+
+	// MaxConcurrency bounds how many groups are parsed in parallel. A
+	// value <= 0 means runtime.GOMAXPROCS(0).
+	MaxConcurrency int
+
+	// ValidationMode controls how thoroughly Parse validates a
+	// structurally sound file before returning it. Lenient (the
+	// default) checks only each record's own fields; Strict and
+	// BankSpecific additionally cross-check control totals, record and
+	// account counts, and continuation placement across the whole
+	// file, returning any violations as a *ValidationError. It's only
+	// consulted once parsing itself succeeds.
+	ValidationMode ValidationMode
+}
+
+// rawGroup is a group's unparsed 02...98 lines, identified during
+// Parse's cheap first pass so the body can be parsed independently of
+// the other groups in the file.
+type rawGroup struct {
+	startLine int
+	lines     []string
+}
+
+// lineError pairs a parse error with the line it came from, so errors
+// gathered out of order across concurrently-parsed groups can be sorted
+// back into file order before being reported.
+type lineError struct {
+	line int
+	err  error
+}
+
+func (e lineError) Error() string { return e.err.Error() }
+func (e lineError) Unwrap() error { return e.err }
+
 // Parse will return file object after parse
 func Parse(fd io.Reader) (*Bai2, error) {
+	return ParseWithOptions(fd, ParseOptions{})
+}
+
+// ParseWithOptions behaves like Parse but accepts ParseOptions
+// controlling error handling and the degree of parallelism used across
+// groups.
+//
+// Record/group boundaries are identified in a first pass using
+// scanRecord and the record code alone; each group's body is then
+// parsed independently, in parallel, via golang.org/x/sync/errgroup.
+func ParseWithOptions(fd io.Reader, opts ParseOptions) (*Bai2, error) {
 	file := NewBai2()
 
 	var lineNum int
-	var group *Group
 	var hasBlock bool
+	var rawGroups []*rawGroup
+	var cur *rawGroup
+	var errs []lineError
+
+	addErr := func(lineNum int, err error) {
+		errs = append(errs, lineError{line: lineNum, err: err})
+	}
 
 	scan := bufio.NewScanner(fd)
+	scan.Buffer(make([]byte, 0, 64*1024), maxScanBufferSize)
 	scan.Split(scanRecord)
 
+	// This pass always runs to completion, identifying every group's
+	// boundaries and recording every top-level error it finds along the
+	// way, regardless of ContinueOnError: a malformed line further down
+	// the file doesn't stop the next group's body (parsed below) from
+	// being examined too, and it's that later pass - not this one -
+	// that can turn up an earlier error than anything found here. Only
+	// once both passes are in do we know which error is truly first.
 	for scan.Scan() {
-
-		// don't expect new line
-		line := strings.ReplaceAll(scan.Text(), "\n", "")
 		lineNum++
 
-		// find record code
-		recordIndex := strings.Index(line, ",")
-		if recordIndex < 2 {
+		code, line, ok := recordCode(scan.Text())
+		if !ok {
 			continue
 		}
-		line = line[recordIndex-2:]
 
-		switch line[0:2] {
+		switch code {
 		case "01":
-
 			newRecord := lib.NewFileHeader()
-			_, err := newRecord.Parse(line)
-			if err != nil {
-				return &file, fmt.Errorf("ERROR parsing file header on line %d - %v", lineNum, err)
+			if _, err := newRecord.Parse(line); err != nil {
+				addErr(lineNum, fmt.Errorf("ERROR parsing file header on line %d - %v", lineNum, err))
+			} else {
+				file.Header = newRecord
+				file.HeaderLine = lineNum
 			}
 
-			file.Header = newRecord
-
 		case "99":
-
 			newRecord := lib.NewFileTrailer()
-			_, err := newRecord.Parse(line)
-			if err != nil {
-				return &file, fmt.Errorf("ERROR parsing file trailer on line %d - %v", lineNum, err)
+			if _, err := newRecord.Parse(line); err != nil {
+				addErr(lineNum, fmt.Errorf("ERROR parsing file trailer on line %d - %v", lineNum, err))
+			} else {
+				file.Trailer = newRecord
+				file.TrailerLine = lineNum
 			}
 
-			file.Trailer = newRecord
-
 		case "02":
+			if cur != nil {
+				addErr(lineNum, fmt.Errorf("ERROR group header on line %d while group started on line %d is still open", lineNum, cur.startLine))
+			}
+			cur = &rawGroup{startLine: lineNum}
+			cur.lines = append(cur.lines, line)
+			rawGroups = append(rawGroups, cur)
 
-			// init group
-			group = NewGroup()
+		case "98":
+			if cur == nil {
+				addErr(lineNum, fmt.Errorf("ERROR group trailer on line %d without a group header", lineNum))
+				continue
+			}
+			cur.lines = append(cur.lines, line)
+			cur = nil
 
-			newRecord := lib.NewGroupHeader()
-			_, err := newRecord.Parse(line)
-			if err != nil {
-				return &file, fmt.Errorf("ERROR parsing file header on line %d - %v", lineNum, err)
+		default:
+			if cur == nil {
+				addErr(lineNum, fmt.Errorf("ERROR record %s on line %d outside of any group", code, lineNum))
+				continue
 			}
+			cur.lines = append(cur.lines, line)
+		}
 
-			group.Header = newRecord
+		hasBlock = true
+	}
 
-		case "98":
+	// scan.Scan() also returns false on a read failure or a token past
+	// maxScanBufferSize, not just on a clean EOF; scan.Err() is how the
+	// two are told apart; Reader.NextRecord checks it for the same
+	// reason. Without this, the buffer widened above to guard against
+	// unterminated input would fail silently instead of being reported.
+	scanErr := scan.Err()
+	if scanErr != nil {
+		addErr(lineNum, fmt.Errorf("ERROR reading input after line %d - %v", lineNum, scanErr))
+	}
 
-			newRecord := lib.NewGroupTrailer()
-			_, err := newRecord.Parse(line)
-			if err != nil {
-				return &file, fmt.Errorf("ERROR parsing file trailer on line %d - %v", lineNum, err)
-			}
+	if !hasBlock {
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		return nil, errors.New("invalid file format")
+	}
 
-			group.Trailer = newRecord
+	limit := opts.MaxConcurrency
+	if limit <= 0 {
+		limit = runtime.GOMAXPROCS(0)
+	}
 
-			// append group
-			file.Groups = append(file.Groups, group)
+	file.Groups = make([]*Group, len(rawGroups))
+
+	var mu sync.Mutex
+	var eg errgroup.Group
+	eg.SetLimit(limit)
+
+	for i, raw := range rawGroups {
+		i, raw := i, raw
+		eg.Go(func() error {
+			group, lerrs := parseGroupLines(raw, opts.ContinueOnError)
+
+			mu.Lock()
+			file.Groups[i] = group
+			errs = append(errs, lerrs...)
+			mu.Unlock()
+
+			// Never surface an error directly: which goroutine
+			// finishes first is a race, and errgroup would report
+			// whichever one got there, making Parse's error
+			// nondeterministic across runs. errs is resolved below
+			// instead, once every group has finished.
+			return nil
+		})
+	}
 
-		case "03":
+	_ = eg.Wait()
 
-			newRecord := lib.NewAccountIdentifier()
-			_, err := newRecord.Parse(line)
-			if err != nil {
-				return &file, fmt.Errorf("ERROR parsing account indentifier on line %d - %v", lineNum, err)
-			}
+	if len(errs) > 0 {
+		// Groups are parsed concurrently, so errors arrive in
+		// completion order rather than file order; sort by line
+		// number so the report is deterministic and reads
+		// top-to-bottom.
+		sort.Slice(errs, func(i, j int) bool { return errs[i].line < errs[j].line })
 
-			group.Details = append(group.Details, newRecord)
+		if !opts.ContinueOnError {
+			return &file, errs[0].err
+		}
 
-		case "49":
+		joined := make([]error, len(errs))
+		for i, e := range errs {
+			joined[i] = e.err
+		}
+		return &file, errors.Join(joined...)
+	}
 
-			newRecord := lib.NewAccountTrailer()
-			_, err := newRecord.Parse(line)
-			if err != nil {
-				return &file, fmt.Errorf("ERROR parsing account trailer on line %d - %v", lineNum, err)
-			}
+	if err := file.ValidateMode(opts.ValidationMode); err != nil {
+		return &file, err
+	}
 
-			group.Details = append(group.Details, newRecord)
+	return &file, nil
+}
 
-		case "16":
+// parseGroupLines parses a single group's 02...98 lines. It touches no
+// shared state, so it's safe to call concurrently across groups. When
+// continueOnError is false, it stops at the first error and returns it
+// as the sole entry in errs.
+func parseGroupLines(raw *rawGroup, continueOnError bool) (*Group, []lineError) {
+	group := NewGroup()
+	var errs []lineError
+
+	// addErr records err and reports whether the caller should stop
+	// processing this group's remaining lines.
+	addErr := func(lineNum int, err error) (stop bool) {
+		errs = append(errs, lineError{line: lineNum, err: err})
+		return !continueOnError
+	}
 
-			newRecord := lib.NewTransactionDetail()
-			_, err := newRecord.Parse(line)
-			if err != nil {
-				return &file, fmt.Errorf("ERROR parsing account transaction detail on line %d - %v", lineNum, err)
-			}
+	for i, line := range raw.lines {
+		lineNum := raw.startLine + i
+		code := line[0:2]
 
-			group.Details = append(group.Details, newRecord)
+		switch code {
+		case "02":
+			newRecord := lib.NewGroupHeader()
+			if _, err := newRecord.Parse(line); err != nil {
+				if addErr(lineNum, fmt.Errorf("ERROR parsing group header on line %d - %v", lineNum, err)) {
+					return group, errs
+				}
+				continue
+			}
+			group.Header = newRecord
+			group.HeaderLine = lineNum
 
-		case "88":
+		case "98":
+			newRecord := lib.NewGroupTrailer()
+			if _, err := newRecord.Parse(line); err != nil {
+				if addErr(lineNum, fmt.Errorf("ERROR parsing group trailer on line %d - %v", lineNum, err)) {
+					return group, errs
+				}
+				continue
+			}
+			group.Trailer = newRecord
+			group.TrailerLine = lineNum
 
-			newRecord := lib.NewContinuationRecord()
-			_, err := newRecord.Parse(line)
+		case "03", "16", "49", "88":
+			rec, err := newRecordFor(code)
+			if err == nil {
+				_, err = rec.Parse(line)
+			}
 			if err != nil {
-				return &file, fmt.Errorf("ERROR parsing continuation of account summary record on line %d - %v", lineNum, err)
+				if addErr(lineNum, fmt.Errorf("ERROR parsing record %s on line %d - %v", code, lineNum, err)) {
+					return group, errs
+				}
+				continue
 			}
-
-			group.Details = append(group.Details, newRecord)
+			group.Details = append(group.Details, rec)
+			group.DetailLines = append(group.DetailLines, lineNum)
 
 		default:
-			continue
-
+			if addErr(lineNum, fmt.Errorf("ERROR unexpected record %s on line %d", code, lineNum)) {
+				return group, errs
+			}
 		}
-
-		hasBlock = true
-
 	}
 
-	if !hasBlock {
-		return nil, errors.New("invalid file format")
-	}
-
-	return &file, nil
+	return group, errs
 }
 
-// scanRecord allows Reader to read each segment
+// maxScanBufferSize bounds how large a single scanRecord token is
+// allowed to grow, as a generous stopgap over the default 64KB
+// bufio.Scanner limit: real BAI2 records are at most a couple hundred
+// bytes, but malformed input lacking a terminator could otherwise run
+// the buffer up against that default and fail with "token too long"
+// before scanRecord gets a chance to report anything more useful.
+const maxScanBufferSize = 10 * 1024 * 1024
+
+// scanRecord is a bufio.SplitFunc that emits one BAI2 record per call.
+// It returns a token as soon as a complete record is found in the
+// buffered data, rather than waiting for atEOF, so Reader and
+// ParseWithOptions can tokenize a stream of any size without first
+// buffering the rest of it.
 func scanRecord(data []byte, atEOF bool) (advance int, token []byte, err error) {
 
 	if atEOF && len(data) == 0 {
@@ -276,8 +493,10 @@ func scanRecord(data []byte, atEOF bool) (advance int, token []byte, err error)
 	}
 
 	index := util.GetSize(string(data))
-	if index < 1 || !atEOF {
-		// need more data
+	if index < 1 {
+		// no complete record in the buffered data yet; ask for more
+		// unless the stream is already exhausted, in which case
+		// there's nothing left to emit.
 		return 0, nil, nil
 	}
 
@@ -0,0 +1,196 @@
+// Copyright 2022 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package file
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/moov-io/bai2/pkg/lib"
+	"github.com/stretchr/testify/require"
+)
+
+// failingReader yields data successfully until it's exhausted, then
+// returns a permanent, non-EOF error - simulating an underlying stream
+// failing partway through, as opposed to a clean or truncated end.
+type failingReader struct {
+	data []byte
+	err  error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, r.err
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestRecordCode(t *testing.T) {
+	cases := []struct {
+		name     string
+		raw      string
+		wantCode string
+		wantLine string
+		wantOK   bool
+	}{
+		{
+			name:     "well formed",
+			raw:      "01,0004,ABC,1,231231,0800,1,80,2/\n",
+			wantCode: "01",
+			wantLine: "01,0004,ABC,1,231231,0800,1,80,2/",
+			wantOK:   true,
+		},
+		{
+			name:   "no comma",
+			raw:    "nonsense",
+			wantOK: false,
+		},
+		{
+			name:   "comma too early to hold a record code",
+			raw:    "0,1,2",
+			wantOK: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			code, line, ok := recordCode(tc.raw)
+			require.Equal(t, tc.wantOK, ok)
+			if tc.wantOK {
+				require.Equal(t, tc.wantCode, code)
+				require.Equal(t, tc.wantLine, line)
+			}
+		})
+	}
+}
+
+func TestNewRecordFor(t *testing.T) {
+	for _, code := range []string{"01", "02", "03", "16", "49", "88", "98", "99"} {
+		rec, err := newRecordFor(code)
+		require.NoError(t, err)
+		require.NotNil(t, rec)
+	}
+
+	_, err := newRecordFor("00")
+	require.Error(t, err)
+}
+
+func TestParseWithOptions_EmptyInput(t *testing.T) {
+	_, err := Parse(strings.NewReader(""))
+	require.Error(t, err)
+}
+
+func TestParseWithOptions_StopsAtEarliestErrorEvenWhenLaterTopLevel(t *testing.T) {
+	// The malformed "16" on line 2 is inside a group body, which is
+	// only parsed in the second pass; the unrecognized "XX" on line 4
+	// is a top-level error found during the first pass. Without waiting
+	// for the group pass, the top-level scan would return on line 4
+	// before line 2's error is ever discovered.
+	input := strings.Join([]string{
+		lib.NewGroupHeader().String(),
+		"16,bad/",
+		lib.NewGroupTrailer().String(),
+		"XX,junk/",
+	}, "\n") + "\n"
+
+	_, err := Parse(strings.NewReader(input))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "line 2")
+}
+
+func TestParseWithOptions_ErrorOrderingIsDeterministic(t *testing.T) {
+	// Two malformed groups whose bodies are parsed concurrently; the
+	// error Parse returns must always point at the earliest line in
+	// the file, regardless of which goroutine happens to finish first.
+	input := "02,bad\n98,bad\n02,bad\n98,bad\n"
+
+	var first string
+	for i := 0; i < 10; i++ {
+		_, err := Parse(strings.NewReader(input))
+		require.Error(t, err)
+		if i == 0 {
+			first = err.Error()
+		} else {
+			require.Equal(t, first, err.Error())
+		}
+	}
+}
+
+func TestParseWithOptions_ContinueOnErrorJoinsEveryMalformedLine(t *testing.T) {
+	// Four independently malformed lines across the top level and two
+	// group bodies; ContinueOnError must surface all four via
+	// errors.Join rather than stopping at the first.
+	input := strings.Join([]string{
+		"XX,junk/",
+		lib.NewGroupHeader().String(),
+		"16,bad/",
+		lib.NewGroupTrailer().String(),
+		lib.NewGroupHeader().String(),
+		"16,bad2/",
+		lib.NewGroupTrailer().String(),
+		"YY,junk/",
+	}, "\n") + "\n"
+
+	_, err := ParseWithOptions(strings.NewReader(input), ParseOptions{ContinueOnError: true})
+	require.Error(t, err)
+
+	require.Contains(t, err.Error(), "line 1")
+	require.Contains(t, err.Error(), "line 3")
+	require.Contains(t, err.Error(), "line 6")
+	require.Contains(t, err.Error(), "line 8")
+
+	var joined interface{ Unwrap() []error }
+	require.ErrorAs(t, err, &joined)
+	require.Len(t, joined.Unwrap(), 4)
+}
+
+func TestParseWithOptions_UnclosedGroupBeforeNextHeaderIsAnError(t *testing.T) {
+	// The group header on line 1 is never closed with a 98 before the
+	// next group's header opens on line 2; that should be reported as
+	// an error, the same way an orphan 98 already is.
+	input := strings.Join([]string{
+		lib.NewGroupHeader().String(),
+		lib.NewGroupHeader().String(),
+		lib.NewGroupTrailer().String(),
+	}, "\n") + "\n"
+
+	_, err := Parse(strings.NewReader(input))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "line 2")
+	require.Contains(t, err.Error(), "still open")
+}
+
+func TestParseWithOptions_SurfacesScanError(t *testing.T) {
+	// Without checking scan.Err() after the scan loop, a read failure
+	// occurring once a valid block has already been seen would be
+	// indistinguishable from a clean end of input.
+	readErr := errors.New("boom")
+	r := &failingReader{data: []byte(lib.NewGroupHeader().String() + "\n"), err: readErr}
+
+	_, err := Parse(r)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), readErr.Error())
+}
+
+func TestParseWithOptions_ContinueOnErrorStillParsesValidGroups(t *testing.T) {
+	// A malformed line in one group shouldn't stop the other, unrelated
+	// group from parsing successfully.
+	input := strings.Join([]string{
+		lib.NewGroupHeader().String(),
+		"16,bad/",
+		lib.NewGroupTrailer().String(),
+		lib.NewGroupHeader().String(),
+		lib.NewGroupTrailer().String(),
+	}, "\n") + "\n"
+
+	file, err := ParseWithOptions(strings.NewReader(input), ParseOptions{ContinueOnError: true})
+	require.Error(t, err)
+
+	require.Len(t, file.Groups, 2)
+	require.NotNil(t, file.Groups[1].Trailer)
+}
@@ -0,0 +1,82 @@
+// Copyright 2022 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package file
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/moov-io/bai2/pkg/lib"
+	"github.com/moov-io/bai2/pkg/record"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSON_RoundTrip(t *testing.T) {
+	header := lib.NewFileHeader()
+	trailer := lib.NewFileTrailer()
+	trailer.NumberOfGroups = 1
+	trailer.NumberOfRecords = 6
+	trailer.FileControlTotal = 500
+
+	groupHeader := lib.NewGroupHeader()
+	groupTrailer := lib.NewGroupTrailer()
+	groupTrailer.NumberOfAccounts = 1
+	groupTrailer.NumberOfRecords = 5
+	groupTrailer.GroupControlTotal = 500
+
+	account := lib.NewAccountIdentifier()
+
+	txn := lib.NewTransactionDetail()
+	txn.Amount = 500
+
+	continuation := lib.NewContinuationRecord()
+
+	accountTrailer := lib.NewAccountTrailer()
+	accountTrailer.AccountControlTotal = 500
+	accountTrailer.NumberOfRecords = 4
+
+	original := &Bai2{
+		Header: header,
+		Groups: []*Group{
+			{
+				Header:  groupHeader,
+				Details: []record.Record{account, txn, continuation, accountTrailer},
+				Trailer: groupTrailer,
+			},
+		},
+		Trailer: trailer,
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, original.ToJSON(&buf))
+
+	restored, err := FromJSON(&buf)
+	require.NoError(t, err)
+
+	require.Equal(t, original.Header, restored.Header)
+	require.Equal(t, original.Trailer, restored.Trailer)
+	require.Len(t, restored.Groups, 1)
+
+	restoredGroup := restored.Groups[0]
+	require.Equal(t, groupHeader, restoredGroup.Header)
+	require.Equal(t, groupTrailer, restoredGroup.Trailer)
+	require.Len(t, restoredGroup.Details, 4)
+
+	restoredAccount, ok := restoredGroup.Details[0].(*lib.AccountIdentifier)
+	require.True(t, ok)
+	require.Equal(t, account, restoredAccount)
+
+	restoredTxn, ok := restoredGroup.Details[1].(*lib.TransactionDetail)
+	require.True(t, ok)
+	require.Equal(t, txn.Amount, restoredTxn.Amount)
+
+	restoredContinuation, ok := restoredGroup.Details[2].(*lib.ContinuationRecord)
+	require.True(t, ok)
+	require.Equal(t, continuation, restoredContinuation)
+
+	restoredAccountTrailer, ok := restoredGroup.Details[3].(*lib.AccountTrailer)
+	require.True(t, ok)
+	require.Equal(t, accountTrailer, restoredAccountTrailer)
+}
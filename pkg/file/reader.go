@@ -0,0 +1,209 @@
+// Copyright 2022 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package file
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+
+	"github.com/moov-io/bai2/pkg/lib"
+	"github.com/moov-io/bai2/pkg/record"
+)
+
+// Reader parses a BAI2 stream one record (or one group) at a time,
+// without accumulating the whole file into a Bai2 the way Parse does.
+// It suits multi-gigabyte bank exports where ETL code wants to act on
+// transactions as they arrive rather than once the whole file is read.
+type Reader struct {
+	scan    *bufio.Scanner
+	lineNum int
+	done    bool
+
+	header  *lib.FileHeader
+	trailer *lib.FileTrailer
+
+	onAccount func(*lib.AccountIdentifier, iter.Seq[*lib.TransactionDetail]) error
+}
+
+// NewReader returns a Reader over fd.
+func NewReader(fd io.Reader) *Reader {
+	scan := bufio.NewScanner(fd)
+	scan.Buffer(make([]byte, 0, 64*1024), maxScanBufferSize)
+	scan.Split(scanRecord)
+	return &Reader{scan: scan}
+}
+
+// OnAccount registers a callback fired by NextGroup each time a 03...49
+// account closes. txns yields the account's 16 records in order and
+// must be consumed before the callback returns.
+func (r *Reader) OnAccount(fn func(*lib.AccountIdentifier, iter.Seq[*lib.TransactionDetail]) error) {
+	r.onAccount = fn
+}
+
+// FileHeader returns the 01 record once NextGroup has read past it, or
+// nil before then.
+func (r *Reader) FileHeader() *lib.FileHeader { return r.header }
+
+// FileTrailer returns the 99 record once NextGroup has read it off the
+// end of the stream, or nil before then.
+func (r *Reader) FileTrailer() *lib.FileTrailer { return r.trailer }
+
+// NextRecord returns the next record in the stream, or io.EOF once the
+// underlying reader is exhausted.
+func (r *Reader) NextRecord() (record.Record, error) {
+	if r.done {
+		return nil, io.EOF
+	}
+
+	for r.scan.Scan() {
+		r.lineNum++
+
+		code, line, ok := recordCode(r.scan.Text())
+		if !ok {
+			continue
+		}
+
+		rec, err := newRecordFor(code)
+		if err != nil {
+			return nil, fmt.Errorf("ERROR on line %d - %v", r.lineNum, err)
+		}
+
+		if _, err := rec.Parse(line); err != nil {
+			return nil, fmt.Errorf("ERROR parsing record %s on line %d - %v", code, r.lineNum, err)
+		}
+
+		return rec, nil
+	}
+
+	r.done = true
+	if err := r.scan.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// nextGroupHeader returns the next group header in the stream,
+// transparently capturing and validating the file header (01) that
+// precedes the first group, and the file trailer (99) that follows the
+// last one. It returns io.EOF once the file trailer has been read, or a
+// wrapped io.ErrUnexpectedEOF if the stream ends beforehand - a clean
+// file always closes with a 99, so anything else reaching EOF first is
+// a truncated file, not an empty one.
+func (r *Reader) nextGroupHeader() (*lib.GroupHeader, error) {
+	for {
+		rec, err := r.NextRecord()
+		if err == io.EOF {
+			// If the file trailer was already read, an earlier call
+			// returned io.EOF for it and this one does the same. If
+			// nothing has been read at all yet, that's a clean empty
+			// input; otherwise the file was truncated mid-stream.
+			if r.trailer != nil || (r.header == nil && r.lineNum == 0) {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("%w: stream ended before a file trailer closed it", io.ErrUnexpectedEOF)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch v := rec.(type) {
+		case *lib.FileHeader:
+			if err := v.Validate(); err != nil {
+				return nil, fmt.Errorf("ERROR validating file header on line %d - %v", r.lineNum, err)
+			}
+			r.header = v
+
+		case *lib.FileTrailer:
+			if err := v.Validate(); err != nil {
+				return nil, fmt.Errorf("ERROR validating file trailer on line %d - %v", r.lineNum, err)
+			}
+			r.trailer = v
+			r.done = true
+			return nil, io.EOF
+
+		case *lib.GroupHeader:
+			return v, nil
+
+		default:
+			return nil, fmt.Errorf("unexpected record %T on line %d, expected a group header", rec, r.lineNum)
+		}
+	}
+}
+
+// NextGroup reads through the next 02...98 group, invoking any
+// registered OnAccount callback as accounts close within it, and
+// returns io.EOF once no groups remain. The file header and trailer
+// bookending the groups are validated as they're encountered and made
+// available via FileHeader/FileTrailer. A stream that ends before the
+// current group's 98 ever arrives is a truncated file, not a clean end,
+// and is reported as a wrapped io.ErrUnexpectedEOF rather than io.EOF.
+func (r *Reader) NextGroup() (*Group, error) {
+	header, err := r.nextGroupHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	headerLine := r.lineNum
+
+	group := NewGroup()
+	group.Header = header
+	group.HeaderLine = headerLine
+
+	var account *lib.AccountIdentifier
+	var txns []*lib.TransactionDetail
+
+	for {
+		rec, err := r.NextRecord()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%w: stream ended before the group header on line %d closed with a group trailer", io.ErrUnexpectedEOF, headerLine)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch v := rec.(type) {
+		case *lib.AccountIdentifier:
+			account, txns = v, nil
+			group.Details = append(group.Details, v)
+			group.DetailLines = append(group.DetailLines, r.lineNum)
+
+		case *lib.TransactionDetail:
+			txns = append(txns, v)
+			group.Details = append(group.Details, v)
+			group.DetailLines = append(group.DetailLines, r.lineNum)
+
+		case *lib.ContinuationRecord:
+			group.Details = append(group.Details, v)
+			group.DetailLines = append(group.DetailLines, r.lineNum)
+
+		case *lib.AccountTrailer:
+			if err := v.Validate(); err != nil {
+				return nil, fmt.Errorf("ERROR validating account trailer on line %d - %v", r.lineNum, err)
+			}
+			group.Details = append(group.Details, v)
+			group.DetailLines = append(group.DetailLines, r.lineNum)
+			if r.onAccount != nil && account != nil {
+				if err := r.onAccount(account, slices.Values(txns)); err != nil {
+					return nil, err
+				}
+			}
+			account, txns = nil, nil
+
+		case *lib.GroupTrailer:
+			if err := v.Validate(); err != nil {
+				return nil, fmt.Errorf("ERROR validating group trailer on line %d - %v", r.lineNum, err)
+			}
+			group.Trailer = v
+			group.TrailerLine = r.lineNum
+			return group, nil
+
+		default:
+			return nil, fmt.Errorf("unexpected record %T within group on line %d", rec, r.lineNum)
+		}
+	}
+}
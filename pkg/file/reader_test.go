@@ -0,0 +1,86 @@
+// Copyright 2022 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package file
+
+import (
+	"io"
+	"iter"
+	"strings"
+	"testing"
+
+	"github.com/moov-io/bai2/pkg/lib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReader_InitialState(t *testing.T) {
+	r := NewReader(strings.NewReader(""))
+	require.Nil(t, r.FileHeader())
+	require.Nil(t, r.FileTrailer())
+
+	_, err := r.NextRecord()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestReader_NextGroup_RejectsNonGroupRecord(t *testing.T) {
+	// A 16 record can't legally open a group.
+	r := NewReader(strings.NewReader("16,115,100,V,092823/\n"))
+
+	_, err := r.NextGroup()
+	require.Error(t, err)
+	require.NotErrorIs(t, err, io.EOF)
+}
+
+func TestReader_NextRecord_HandlesInputLargerThanDefaultScanBuffer(t *testing.T) {
+	// bufio.Scanner's default MaxScanTokenSize is 64KB; build an input
+	// well past that and check that NextRecord emits every record in
+	// it, one at a time, without hitting "token too long".
+	const line = "16,115,100,V,092823/\n"
+	const count = 64*1024/len(line) + 1000
+
+	r := NewReader(strings.NewReader(strings.Repeat(line, count)))
+
+	var got int
+	for {
+		_, err := r.NextRecord()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		got++
+	}
+	require.Equal(t, count, got)
+}
+
+func TestReader_NextGroup_TruncatedAfterFileHeaderIsUnexpectedEOF(t *testing.T) {
+	// The stream ends right after the 01, with no group and no 99 - a
+	// truncated file, not a clean empty one.
+	r := NewReader(strings.NewReader(lib.NewFileHeader().String() + "\n"))
+
+	_, err := r.NextGroup()
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.NotErrorIs(t, err, io.EOF)
+}
+
+func TestReader_NextGroup_TruncatedMidGroupIsUnexpectedEOF(t *testing.T) {
+	// The group header arrives but the stream ends before its 98
+	// trailer ever does.
+	r := NewReader(strings.NewReader(lib.NewGroupHeader().String() + "\n"))
+
+	_, err := r.NextGroup()
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.NotErrorIs(t, err, io.EOF)
+}
+
+func TestReader_OnAccount_RegistersCallback(t *testing.T) {
+	r := NewReader(strings.NewReader(""))
+
+	var called bool
+	r.OnAccount(func(_ *lib.AccountIdentifier, _ iter.Seq[*lib.TransactionDetail]) error {
+		called = true
+		return nil
+	})
+
+	require.False(t, called) // registering alone shouldn't invoke it
+}
@@ -0,0 +1,234 @@
+// Copyright 2022 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package file
+
+import (
+	"fmt"
+
+	"github.com/moov-io/bai2/pkg/lib"
+)
+
+// ValidationMode selects how thoroughly ValidateMode checks a parsed
+// file.
+type ValidationMode int
+
+const (
+	// Lenient validates only each record's own fields, exactly as
+	// Validate has always done.
+	Lenient ValidationMode = iota
+
+	// Strict additionally cross-checks control totals, record/account
+	// counts, and continuation placement across the whole file.
+	Strict
+
+	// BankSpecific is a deliberate placeholder for bank-specific
+	// conventions (e.g. a particular bank's non-standard record-code or
+	// field-format quirks) that callers may need layered on top of
+	// Strict. No such conventions exist yet, so BankSpecific runs
+	// exactly Strict's checks today; it's a distinct constant so that
+	// adding them later doesn't change Strict's meaning for existing
+	// callers.
+	BankSpecific
+)
+
+// Violation describes a single cross-record mismatch found in Strict or
+// BankSpecific mode. Line is the source line of the record the
+// violation was reported against (a trailer for a total/count mismatch,
+// or the continuation record itself for a placement violation); it's
+// zero when Bai2/Group weren't populated by Parse, e.g. one built by
+// hand via Writer.
+type Violation struct {
+	Line     int
+	Message  string
+	Expected string
+	Actual   string
+}
+
+func (v Violation) String() string {
+	msg := v.Message
+	if v.Expected != "" || v.Actual != "" {
+		msg = fmt.Sprintf("%s (expected %s, got %s)", msg, v.Expected, v.Actual)
+	}
+	if v.Line > 0 {
+		return fmt.Sprintf("line %d: %s", v.Line, msg)
+	}
+	return msg
+}
+
+// ValidationError collects every cross-record validation failure found
+// in Strict or BankSpecific mode, rather than stopping at the first
+// one.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Violations) == 1 {
+		return e.Violations[0].String()
+	}
+	return fmt.Sprintf("%d validation violations, first: %s", len(e.Violations), e.Violations[0].String())
+}
+
+// ValidateMode runs Validate and, in Strict or BankSpecific mode, also
+// cross-checks control totals, record/account counts, and continuation
+// placement across the whole file.
+func (r *Bai2) ValidateMode(mode ValidationMode) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	if mode == Lenient {
+		return nil
+	}
+
+	var violations []Violation
+	var fileTotal int64
+	var fileRecords int
+
+	if r.Header != nil {
+		fileRecords++
+	}
+
+	for _, g := range r.Groups {
+		total, records, v := validateGroup(g)
+		violations = append(violations, v...)
+		fileTotal += total
+		fileRecords += records
+	}
+
+	if r.Trailer != nil {
+		if r.Trailer.FileControlTotal != fileTotal {
+			violations = append(violations, Violation{
+				Line:     r.TrailerLine,
+				Message:  "file control total does not match sum of group totals",
+				Expected: fmt.Sprint(fileTotal),
+				Actual:   fmt.Sprint(r.Trailer.FileControlTotal),
+			})
+		}
+		if r.Trailer.NumberOfGroups != len(r.Groups) {
+			violations = append(violations, Violation{
+				Line:     r.TrailerLine,
+				Message:  "file trailer group count does not match observed groups",
+				Expected: fmt.Sprint(len(r.Groups)),
+				Actual:   fmt.Sprint(r.Trailer.NumberOfGroups),
+			})
+		}
+		// + the 99 itself
+		if r.Trailer.NumberOfRecords != fileRecords+1 {
+			violations = append(violations, Violation{
+				Line:     r.TrailerLine,
+				Message:  "file trailer record count does not match observed records",
+				Expected: fmt.Sprint(fileRecords + 1),
+				Actual:   fmt.Sprint(r.Trailer.NumberOfRecords),
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+// validateGroup cross-checks one group's account/transaction totals,
+// record counts, and continuation placement. It returns the group's
+// control total and its total record count (header + details + trailer)
+// for the caller to roll up into the file-level checks.
+func validateGroup(g *Group) (int64, int, []Violation) {
+	var violations []Violation
+	var groupTotal, accountTotal int64
+	var numAccounts, accountRecords int
+	var prevCode string
+	var accountTrailerLine int
+
+	// lineAt returns the source line of Details[i], or zero if g wasn't
+	// populated by Parse.
+	lineAt := func(i int) int {
+		if i < len(g.DetailLines) {
+			return g.DetailLines[i]
+		}
+		return 0
+	}
+
+	for i, d := range g.Details {
+		switch rec := d.(type) {
+		case *lib.AccountIdentifier:
+			accountTotal = 0
+			accountRecords = 1 // the 03 itself
+
+		case *lib.TransactionDetail:
+			accountTotal += rec.Amount
+			accountRecords++
+
+		case *lib.ContinuationRecord:
+			if prevCode != "03" && prevCode != "16" && prevCode != "88" {
+				violations = append(violations, Violation{
+					Line:    lineAt(i),
+					Message: "continuation record does not follow a continuable record",
+				})
+			}
+			accountRecords++
+
+		case *lib.AccountTrailer:
+			accountTrailerLine = lineAt(i)
+			if rec.AccountControlTotal != accountTotal {
+				violations = append(violations, Violation{
+					Line:     accountTrailerLine,
+					Message:  "account control total does not match sum of transaction amounts",
+					Expected: fmt.Sprint(accountTotal),
+					Actual:   fmt.Sprint(rec.AccountControlTotal),
+				})
+			}
+			// + the 49 itself
+			if rec.NumberOfRecords != accountRecords+1 {
+				violations = append(violations, Violation{
+					Line:     accountTrailerLine,
+					Message:  "account trailer record count does not match observed records",
+					Expected: fmt.Sprint(accountRecords + 1),
+					Actual:   fmt.Sprint(rec.NumberOfRecords),
+				})
+			}
+			groupTotal += accountTotal
+			numAccounts++
+		}
+
+		prevCode = recordCodeOf(d)
+	}
+
+	groupRecords := len(g.Details)
+	if g.Header != nil {
+		groupRecords++
+	}
+
+	if g.Trailer != nil {
+		if g.Trailer.GroupControlTotal != groupTotal {
+			violations = append(violations, Violation{
+				Line:     g.TrailerLine,
+				Message:  "group control total does not match sum of account totals",
+				Expected: fmt.Sprint(groupTotal),
+				Actual:   fmt.Sprint(g.Trailer.GroupControlTotal),
+			})
+		}
+		if g.Trailer.NumberOfAccounts != numAccounts {
+			violations = append(violations, Violation{
+				Line:     g.TrailerLine,
+				Message:  "group trailer account count does not match observed accounts",
+				Expected: fmt.Sprint(numAccounts),
+				Actual:   fmt.Sprint(g.Trailer.NumberOfAccounts),
+			})
+		}
+		// + the 98 itself
+		if g.Trailer.NumberOfRecords != groupRecords+1 {
+			violations = append(violations, Violation{
+				Line:     g.TrailerLine,
+				Message:  "group trailer record count does not match observed records",
+				Expected: fmt.Sprint(groupRecords + 1),
+				Actual:   fmt.Sprint(g.Trailer.NumberOfRecords),
+			})
+		}
+		groupRecords++ // include the 98 itself in the file-level rollup
+	}
+
+	return groupTotal, groupRecords, violations
+}
@@ -0,0 +1,196 @@
+// Copyright 2022 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package file
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/moov-io/bai2/pkg/lib"
+	"github.com/moov-io/bai2/pkg/record"
+	"github.com/stretchr/testify/require"
+)
+
+func buildValidFile(t *testing.T) *Bai2 {
+	t.Helper()
+
+	account := lib.NewAccountIdentifier()
+	txn := lib.NewTransactionDetail()
+	txn.Amount = 500
+
+	accountTrailer := lib.NewAccountTrailer()
+	accountTrailer.AccountControlTotal = 500
+	accountTrailer.NumberOfRecords = 3 // 03 + 16 + 49
+
+	groupTrailer := lib.NewGroupTrailer()
+	groupTrailer.GroupControlTotal = 500
+	groupTrailer.NumberOfAccounts = 1
+	groupTrailer.NumberOfRecords = 5 // 02 + 03 + 16 + 49 + 98
+
+	group := &Group{
+		Header:  lib.NewGroupHeader(),
+		Details: []record.Record{account, txn, accountTrailer},
+		Trailer: groupTrailer,
+	}
+
+	fileTrailer := lib.NewFileTrailer()
+	fileTrailer.FileControlTotal = 500
+	fileTrailer.NumberOfGroups = 1
+	fileTrailer.NumberOfRecords = 6 // 5 group records + 99 (no file header)
+
+	return &Bai2{
+		Groups:  []*Group{group},
+		Trailer: fileTrailer,
+	}
+}
+
+func TestValidateMode_Lenient_SkipsCrossChecks(t *testing.T) {
+	bai2 := buildValidFile(t)
+	bai2.Trailer.NumberOfRecords = 999 // would fail Strict
+
+	require.NoError(t, bai2.ValidateMode(Lenient))
+}
+
+func TestValidateMode_Strict_PassesOnConsistentFile(t *testing.T) {
+	bai2 := buildValidFile(t)
+	require.NoError(t, bai2.ValidateMode(Strict))
+}
+
+func TestValidateMode_Strict_CatchesAccountTrailerRecordCountMismatch(t *testing.T) {
+	bai2 := buildValidFile(t)
+	bai2.Groups[0].Details[2].(*lib.AccountTrailer).NumberOfRecords = 1
+
+	err := bai2.ValidateMode(Strict)
+	require.Error(t, err)
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Contains(t, verr.Error(), "account trailer record count")
+}
+
+func TestValidateMode_Strict_CatchesGroupTrailerRecordCountMismatch(t *testing.T) {
+	bai2 := buildValidFile(t)
+	bai2.Groups[0].Trailer.NumberOfRecords = 1
+
+	err := bai2.ValidateMode(Strict)
+	require.Error(t, err)
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Contains(t, verr.Error(), "group trailer record count")
+}
+
+func TestValidateMode_Strict_CatchesFileTrailerRecordCountMismatch(t *testing.T) {
+	bai2 := buildValidFile(t)
+	bai2.Trailer.NumberOfRecords = 1
+
+	err := bai2.ValidateMode(Strict)
+	require.Error(t, err)
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Contains(t, verr.Error(), "file trailer record count")
+}
+
+func TestValidateMode_BankSpecific_MatchesStrict(t *testing.T) {
+	bai2 := buildValidFile(t)
+	bai2.Trailer.NumberOfRecords = 1
+
+	strictErr := bai2.ValidateMode(Strict)
+	bankErr := bai2.ValidateMode(BankSpecific)
+	require.Equal(t, strictErr, bankErr)
+}
+
+func TestValidateMode_Strict_CatchesAccountControlTotalMismatch(t *testing.T) {
+	bai2 := buildValidFile(t)
+	bai2.Groups[0].Details[2].(*lib.AccountTrailer).AccountControlTotal = 999
+
+	err := bai2.ValidateMode(Strict)
+	require.Error(t, err)
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Contains(t, verr.Error(), "account control total")
+}
+
+func TestValidateMode_Strict_CatchesGroupControlTotalMismatch(t *testing.T) {
+	bai2 := buildValidFile(t)
+	bai2.Groups[0].Trailer.GroupControlTotal = 999
+
+	err := bai2.ValidateMode(Strict)
+	require.Error(t, err)
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Contains(t, verr.Error(), "group control total")
+}
+
+func TestValidateMode_Strict_CatchesFileControlTotalMismatch(t *testing.T) {
+	bai2 := buildValidFile(t)
+	bai2.Trailer.FileControlTotal = 999
+
+	err := bai2.ValidateMode(Strict)
+	require.Error(t, err)
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Contains(t, verr.Error(), "file control total")
+}
+
+func TestValidateMode_Strict_CatchesContinuationFollowingIllegitimateRecord(t *testing.T) {
+	bai2 := buildValidFile(t)
+
+	// A continuation record can only legally follow a 03, 16, or 88; here
+	// it directly follows the 49 that closed the account.
+	bai2.Groups[0].Details = append(bai2.Groups[0].Details, lib.NewContinuationRecord())
+	bai2.Groups[0].Trailer.NumberOfRecords++ // keep the group count check from also firing
+	bai2.Trailer.NumberOfRecords++           // keep the file count check from also firing
+
+	err := bai2.ValidateMode(Strict)
+	require.Error(t, err)
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Contains(t, verr.Error(), "continuation record does not follow a continuable record")
+}
+
+func TestValidateMode_Strict_ReportsViolationLineNumbers(t *testing.T) {
+	input := strings.Join([]string{
+		lib.NewGroupHeader().String(),
+		lib.NewGroupTrailer().String(),
+	}, "\n") + "\n"
+
+	bai2, err := Parse(strings.NewReader(input))
+	require.NoError(t, err)
+
+	bai2.Groups[0].Trailer.NumberOfAccounts = 1 // force a mismatch against the observed 0
+
+	err = bai2.ValidateMode(Strict)
+	require.Error(t, err)
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Equal(t, bai2.Groups[0].TrailerLine, verr.Violations[0].Line)
+	require.Contains(t, verr.Violations[0].String(), fmt.Sprintf("line %d", bai2.Groups[0].TrailerLine))
+}
+
+func TestParseWithOptions_ValidationModeIsPlumbedThrough(t *testing.T) {
+	input := strings.Join([]string{
+		lib.NewGroupHeader().String(),
+		lib.NewGroupTrailer().String(),
+	}, "\n") + "\n"
+
+	// Lenient (the default) doesn't cross-check, even though the group
+	// trailer's zero-value counts don't match the lone group observed.
+	_, err := Parse(strings.NewReader(input))
+	require.NoError(t, err)
+
+	_, err = ParseWithOptions(strings.NewReader(input), ParseOptions{ValidationMode: Strict})
+	require.Error(t, err)
+
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+}
@@ -0,0 +1,265 @@
+// Copyright 2022 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package file
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/moov-io/bai2/pkg/lib"
+	"github.com/moov-io/bai2/pkg/record"
+)
+
+// WriterOptions configures the physical layout a Writer produces.
+type WriterOptions struct {
+	// PhysicalRecordLength is the maximum line length before a 16
+	// record must be continued onto one or more 88 records. Defaults
+	// to 80.
+	PhysicalRecordLength int
+
+	// BlockSize is the number of physical records per block. When > 0,
+	// Writer.BlockCount reports how many blocks Close's output spans;
+	// when 0, block counting is skipped.
+	BlockSize int
+}
+
+func (o WriterOptions) withDefaults() WriterOptions {
+	if o.PhysicalRecordLength <= 0 {
+		o.PhysicalRecordLength = 80
+	}
+	return o
+}
+
+// Writer builds a Bai2 file one header/account/transaction at a time and
+// computes the control totals and counts the 49, 98, and 99 trailers
+// require, so callers don't have to hand-sum them.
+type Writer struct {
+	opts       WriterOptions
+	file       *Bai2
+	group      *groupBuilder
+	blockCount int
+}
+
+type groupBuilder struct {
+	header      *lib.GroupHeader
+	details     []record.Record
+	account     *accountBuilder
+	groupTotal  int64
+	numAccounts int
+	numRecords  int // includes the 02 header as it's opened
+}
+
+type accountBuilder struct {
+	identifier *lib.AccountIdentifier
+	total      int64
+	numRecords int // includes the 03 identifier as it's opened
+}
+
+// NewWriter creates a Writer that computes trailers according to opts
+// once Close is called.
+func NewWriter(opts WriterOptions) *Writer {
+	return &Writer{opts: opts.withDefaults(), file: &Bai2{}}
+}
+
+// SetFileHeader sets the file's 01 record.
+func (w *Writer) SetFileHeader(h *lib.FileHeader) {
+	w.file.Header = h
+}
+
+// BeginGroup starts a new 02 group. The previously started group, if
+// any, must already have been closed with EndGroup.
+func (w *Writer) BeginGroup(h *lib.GroupHeader) error {
+	if w.group != nil {
+		return errors.New("a group is still open")
+	}
+	w.group = &groupBuilder{header: h, numRecords: 1}
+	return nil
+}
+
+// BeginAccount starts a new 03 account within the current group.
+func (w *Writer) BeginAccount(id *lib.AccountIdentifier) error {
+	if w.group == nil {
+		return errors.New("no open group")
+	}
+	if w.group.account != nil {
+		return errors.New("an account is still open")
+	}
+
+	w.group.account = &accountBuilder{identifier: id, numRecords: 1}
+	w.group.details = append(w.group.details, id)
+	w.group.numRecords++
+	return nil
+}
+
+// WriteTransaction appends a 16 record to the current account, splitting
+// it across one or more 88 continuation records whenever its rendered
+// line exceeds the configured physical record length.
+func (w *Writer) WriteTransaction(t *lib.TransactionDetail) error {
+	if w.group == nil || w.group.account == nil {
+		return errors.New("no open account")
+	}
+
+	head, continuations := splitRecordLine(t.String(), w.opts.PhysicalRecordLength)
+
+	detail := record.Record(t)
+	if len(continuations) > 0 {
+		truncated := lib.NewTransactionDetail()
+		if _, err := truncated.Parse(head); err != nil {
+			return fmt.Errorf("ERROR truncating transaction detail - %v", err)
+		}
+		// Splitting fields onto 88 continuations is only safe once the
+		// record's own business data - its Amount - is captured in head;
+		// the trailers below are totaled from t.Amount directly, so a
+		// head this short would store a 16 record whose own Amount field
+		// disagrees with them.
+		if truncated.Amount != t.Amount {
+			return fmt.Errorf("ERROR PhysicalRecordLength %d splits the 16 record before its amount field is captured", w.opts.PhysicalRecordLength)
+		}
+		detail = truncated
+	}
+
+	w.group.details = append(w.group.details, detail)
+	w.group.numRecords++
+	w.group.account.numRecords++
+	w.group.account.total += t.Amount
+	w.group.groupTotal += t.Amount
+
+	for _, line := range continuations {
+		cont := lib.NewContinuationRecord()
+		if _, err := cont.Parse(line); err != nil {
+			return fmt.Errorf("ERROR building continuation record - %v", err)
+		}
+		w.group.details = append(w.group.details, cont)
+		w.group.numRecords++
+		w.group.account.numRecords++
+	}
+
+	return nil
+}
+
+// EndAccount closes the current account, appending its computed 49
+// trailer.
+func (w *Writer) EndAccount() error {
+	if w.group == nil || w.group.account == nil {
+		return errors.New("no open account")
+	}
+
+	a := w.group.account
+	trailer := lib.NewAccountTrailer()
+	trailer.AccountControlTotal = a.total
+	trailer.NumberOfRecords = a.numRecords + 1 // + the 49 itself
+
+	w.group.details = append(w.group.details, trailer)
+	w.group.numRecords++ // the 49 itself; 03/16/88 were already counted as written
+	w.group.numAccounts++
+	w.group.account = nil
+	return nil
+}
+
+// EndGroup closes the current group, appending its computed 98 trailer.
+func (w *Writer) EndGroup() error {
+	if w.group == nil {
+		return errors.New("no open group")
+	}
+	if w.group.account != nil {
+		return errors.New("an account is still open")
+	}
+
+	trailer := lib.NewGroupTrailer()
+	trailer.GroupControlTotal = w.group.groupTotal
+	trailer.NumberOfAccounts = w.group.numAccounts
+	trailer.NumberOfRecords = w.group.numRecords + 1 // + the 98 itself
+
+	w.file.Groups = append(w.file.Groups, &Group{
+		Header:  w.group.header,
+		Details: w.group.details,
+		Trailer: trailer,
+	})
+	w.group = nil
+	return nil
+}
+
+// Close finalizes the file, computing the 99 trailer, and returns the
+// completed Bai2.
+func (w *Writer) Close() (*Bai2, error) {
+	if w.group != nil {
+		return nil, errors.New("a group is still open")
+	}
+
+	var fileTotal int64
+	var numRecords int
+	if w.file.Header != nil {
+		numRecords++
+	}
+	for _, g := range w.file.Groups {
+		fileTotal += g.Trailer.GroupControlTotal
+		numRecords += len(g.Details) + 2 // header + trailer
+	}
+
+	trailer := lib.NewFileTrailer()
+	trailer.FileControlTotal = fileTotal
+	trailer.NumberOfGroups = len(w.file.Groups)
+	trailer.NumberOfRecords = numRecords + 1
+	w.file.Trailer = trailer
+
+	if w.opts.BlockSize > 0 {
+		w.blockCount = (numRecords + 1 + w.opts.BlockSize - 1) / w.opts.BlockSize
+	}
+
+	return w.file, nil
+}
+
+// BlockCount reports how many WriterOptions.BlockSize-sized blocks
+// Close's output spans. It's zero until Close has run, and stays zero
+// if BlockSize was never configured.
+func (w *Writer) BlockCount() int {
+	return w.blockCount
+}
+
+// continuationPrefix is what an 88 record's rendered line always starts
+// with, budgeted for when packing continuation lines below.
+const continuationPrefix = "88,"
+
+// splitRecordLine packs a record's comma-delimited fields into a head
+// line of at most physicalLen bytes, plus as many fully rendered 88,...
+// continuation lines as needed to carry the rest. Fields are never cut
+// mid-value, so head, joined with the continuations' data (stripped of
+// their "88," prefix), reconstructs line exactly.
+func splitRecordLine(line string, physicalLen int) (head string, continuations []string) {
+	if physicalLen <= 0 || len(line) <= physicalLen {
+		return line, nil
+	}
+
+	fields := strings.Split(line, ",")
+
+	var lines []string
+	var cur string
+	limit := physicalLen // the head line carries no "88," prefix
+
+	for _, f := range fields {
+		candidate := f
+		if cur != "" {
+			candidate = cur + "," + f
+		}
+
+		if cur != "" && len(candidate) > limit {
+			lines = append(lines, cur)
+			cur = f
+			limit = physicalLen - len(continuationPrefix)
+			continue
+		}
+		cur = candidate
+	}
+	if cur != "" {
+		lines = append(lines, cur)
+	}
+
+	head = lines[0]
+	for _, l := range lines[1:] {
+		continuations = append(continuations, continuationPrefix+l)
+	}
+	return head, continuations
+}
@@ -0,0 +1,177 @@
+// Copyright 2022 The Moov Authors
+// Use of this source code is governed by an Apache License
+// license that can be found in the LICENSE file.
+
+package file
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/moov-io/bai2/pkg/lib"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitRecordLine_FitsWithinLimit(t *testing.T) {
+	head, continuations := splitRecordLine("16,115,100,V,092823/", 80)
+	require.Equal(t, "16,115,100,V,092823/", head)
+	require.Empty(t, continuations)
+}
+
+func TestSplitRecordLine_SplitsAtFieldBoundaries(t *testing.T) {
+	fields := []string{"16", "115", "000000010000", "V", "092823"}
+	for i := 0; i < 12; i++ {
+		fields = append(fields, strings.Repeat("X", 10))
+	}
+	line := strings.Join(fields, ",") + "/"
+
+	const physicalLen = 80
+	head, continuations := splitRecordLine(line, physicalLen)
+
+	require.NotEmpty(t, continuations)
+	require.LessOrEqual(t, len(head), physicalLen)
+	for _, c := range continuations {
+		require.LessOrEqual(t, len(c), physicalLen)
+		require.True(t, strings.HasPrefix(c, "88,"))
+		// no field was cut mid-value: every piece is one of line's own fields
+		for _, f := range strings.Split(strings.TrimPrefix(c, "88,"), ",") {
+			require.Contains(t, strings.Split(line, ","), f)
+		}
+	}
+
+	// head + continuations (less their "88," prefix) reconstructs line
+	// exactly, byte for byte.
+	rebuilt := head
+	for _, c := range continuations {
+		rebuilt += "," + strings.TrimPrefix(c, "88,")
+	}
+	require.Equal(t, line, rebuilt)
+}
+
+func TestWriter_WriteTransaction_SplitsLongLineAndRoundTrips(t *testing.T) {
+	txn := lib.NewTransactionDetail()
+	txn.Amount = 123456789
+
+	// A physical length one byte short of the full line forces the
+	// smallest possible split - just the trailing field - without
+	// risking cutting into the fields splitRecordLine packs first,
+	// Amount among them.
+	physicalLen := len(txn.String()) - 1
+
+	w := NewWriter(WriterOptions{PhysicalRecordLength: physicalLen})
+	require.NoError(t, w.BeginGroup(lib.NewGroupHeader()))
+	require.NoError(t, w.BeginAccount(lib.NewAccountIdentifier()))
+
+	wantHead, wantContinuations := splitRecordLine(txn.String(), physicalLen)
+	require.NotEmpty(t, wantContinuations, "fixture must actually exercise splitting")
+
+	require.NoError(t, w.WriteTransaction(txn))
+
+	// details[0] is the 03 account identifier; the transaction's head
+	// and continuations follow it.
+	details := w.group.details
+	require.Len(t, details, 2+len(wantContinuations))
+
+	gotHead, ok := details[1].(*lib.TransactionDetail)
+	require.True(t, ok)
+	require.Equal(t, wantHead, gotHead.String())
+
+	for i, wantLine := range wantContinuations {
+		gotCont, ok := details[2+i].(*lib.ContinuationRecord)
+		require.True(t, ok)
+		require.Equal(t, wantLine, gotCont.String())
+	}
+
+	// The stored records reconstruct the original, over-length line
+	// exactly, confirming the split round-trips through Parse/String.
+	rebuilt := gotHead.String()
+	for _, wantLine := range wantContinuations {
+		rebuilt += "," + strings.TrimPrefix(wantLine, continuationPrefix)
+	}
+	require.Equal(t, txn.String(), rebuilt)
+
+	// The total stays correct even though the 16 line itself got split.
+	require.Equal(t, txn.Amount, w.group.account.total)
+}
+
+func TestWriter_WriteTransaction_ErrorsWhenSplitCutsOffAmount(t *testing.T) {
+	// A physical length this small forces a split before the Amount
+	// field is ever captured in head; writing it would store a 16
+	// record whose own Amount disagrees with the 49/98/99 totals, which
+	// are summed from the original, unsplit t.Amount.
+	const physicalLen = 5
+
+	w := NewWriter(WriterOptions{PhysicalRecordLength: physicalLen})
+	require.NoError(t, w.BeginGroup(lib.NewGroupHeader()))
+	require.NoError(t, w.BeginAccount(lib.NewAccountIdentifier()))
+
+	txn := lib.NewTransactionDetail()
+	txn.Amount = 123456789
+
+	err := w.WriteTransaction(txn)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "PhysicalRecordLength")
+}
+
+func TestWriter_BlockCount_ComputedFromBlockSize(t *testing.T) {
+	w := NewWriter(WriterOptions{BlockSize: 2})
+
+	require.NoError(t, w.BeginGroup(lib.NewGroupHeader()))
+	require.NoError(t, w.BeginAccount(lib.NewAccountIdentifier()))
+
+	txn := lib.NewTransactionDetail()
+	txn.Amount = 500
+	require.NoError(t, w.WriteTransaction(txn))
+
+	require.NoError(t, w.EndAccount())
+	require.NoError(t, w.EndGroup())
+
+	require.Equal(t, 0, w.BlockCount(), "zero until Close has run")
+
+	bai2, err := w.Close()
+	require.NoError(t, err)
+
+	// 02 + 03 + 16 + 49 + 98 + 99 = 6 records, at 2 per block = 3 blocks
+	require.Equal(t, 6, bai2.Trailer.NumberOfRecords)
+	require.Equal(t, 3, w.BlockCount())
+}
+
+func TestWriter_BlockCount_StaysZeroWithoutBlockSize(t *testing.T) {
+	w := NewWriter(WriterOptions{})
+
+	require.NoError(t, w.BeginGroup(lib.NewGroupHeader()))
+	require.NoError(t, w.EndGroup())
+
+	_, err := w.Close()
+	require.NoError(t, err)
+
+	require.Zero(t, w.BlockCount())
+}
+
+func TestWriter_TrailerCounts(t *testing.T) {
+	w := NewWriter(WriterOptions{})
+
+	require.NoError(t, w.BeginGroup(lib.NewGroupHeader()))
+	require.NoError(t, w.BeginAccount(lib.NewAccountIdentifier()))
+
+	txn := lib.NewTransactionDetail()
+	txn.Amount = 500
+	require.NoError(t, w.WriteTransaction(txn))
+
+	require.NoError(t, w.EndAccount())
+	require.NoError(t, w.EndGroup())
+
+	bai2, err := w.Close()
+	require.NoError(t, err)
+
+	group := bai2.Groups[0]
+	require.Equal(t, int64(500), group.Trailer.GroupControlTotal)
+	require.Equal(t, 1, group.Trailer.NumberOfAccounts)
+	// 02 + 03 + 16 + 49 + 98
+	require.Equal(t, 5, group.Trailer.NumberOfRecords)
+
+	require.Equal(t, int64(500), bai2.Trailer.FileControlTotal)
+	require.Equal(t, 1, bai2.Trailer.NumberOfGroups)
+	// 5 group records + 99 (no file header was set)
+	require.Equal(t, 6, bai2.Trailer.NumberOfRecords)
+}